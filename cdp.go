@@ -0,0 +1,457 @@
+package chromium
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	websocket "github.com/gorilla/websocket"
+)
+
+// maxReconnectAttempts bounds how many times in a row reconnect redials
+// after the connection drops before giving up and treating the Client as
+// closed.
+const maxReconnectAttempts = 5
+
+// Target describes a single debuggable target (page, worker, or the browser
+// itself) as reported by Chrome's HTTP discovery endpoints.
+type Target struct {
+	ID                   string `json:"id"`
+	Type                 string `json:"type"`
+	Title                string `json:"title"`
+	URL                  string `json:"url"`
+	WebSocketDebuggerURL string `json:"webSocketDebuggerUrl"`
+}
+
+// Event is a Chrome DevTools Protocol event delivered to subscribers
+// registered through Client.Subscribe.
+type Event struct {
+	SessionID string
+	Method    string
+	Params    json.RawMessage
+}
+
+// Client is a connection to a Chromium instance's remote debugging endpoint,
+// speaking the Chrome DevTools Protocol directly over its WebSocket
+// transport. A Client is safe for concurrent use.
+type Client struct {
+	id   uint64
+	done chan struct{}
+
+	// dial redials the same endpoint after the connection drops, letting
+	// read transparently reconnect. It's nil for a Client that shouldn't
+	// reconnect.
+	dial func() (*websocket.Conn, error)
+
+	mutex    sync.Mutex
+	conn     *websocket.Conn
+	closing  bool
+	pending  map[uint64]pendingRequest
+	subs     map[string][]chan Event
+	sessions map[string]bool
+
+	// writeMutex serializes writes to conn. It's separate from mutex, which
+	// only guards the conn pointer itself (plus pending/subs/closing): a
+	// gorilla/websocket connection isn't safe for concurrent writers, so
+	// every WriteJSON call must hold writeMutex for its full duration, not
+	// just for the moment it reads the pointer.
+	writeMutex sync.Mutex
+
+	// watchDetach starts watching Target.detachedFromTarget the first time
+	// Attach is called, so a target-initiated detach cleans a session up
+	// the same way calling Detach would.
+	watchDetach sync.Once
+}
+
+// pendingRequest is an in-flight Send call awaiting its reply. sessionID is
+// kept alongside reply so a session that detaches can fail just its own
+// requests without disturbing anyone else's.
+type pendingRequest struct {
+	sessionID string
+	reply     chan cdpResponse
+}
+
+type cdpRequest struct {
+	ID        uint64      `json:"id"`
+	SessionID string      `json:"sessionId,omitempty"`
+	Method    string      `json:"method"`
+	Params    interface{} `json:"params,omitempty"`
+}
+
+type cdpResponse struct {
+	ID        uint64          `json:"id"`
+	SessionID string          `json:"sessionId,omitempty"`
+	Result    json.RawMessage `json:"result"`
+	Error     *cdpError       `json:"error"`
+}
+
+type cdpError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type cdpEvent struct {
+	SessionID string          `json:"sessionId,omitempty"`
+	Method    string          `json:"method"`
+	Params    json.RawMessage `json:"params"`
+}
+
+// Targets lists the targets currently debuggable on the given remote
+// debugging address, as reported by GET /json.
+func Targets(addr string, port uint16) ([]Target, error) {
+	var targets []Target
+
+	if err := getJSON(fmt.Sprintf("http://%s:%d/json", addr, port), &targets); err != nil {
+		return nil, err
+	}
+
+	return targets, nil
+}
+
+// Version describes the browser-level target returned by GET /json/version,
+// most notably its WebSocketDebuggerURL.
+func Version(addr string, port uint16) (Target, error) {
+	var target Target
+
+	err := getJSON(fmt.Sprintf("http://%s:%d/json/version", addr, port), &target)
+
+	return target, err
+}
+
+// Dial opens a Client connected to the browser-level CDP endpoint at
+// addr:port, as discovered via /json/version.
+func Dial(addr string, port uint16) (*Client, error) {
+	target, err := Version(addr, port)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return DialTarget(target.WebSocketDebuggerURL)
+}
+
+// DialTarget opens a Client connected directly to a target's WebSocket
+// debugger URL, as returned by Targets or Version. If the connection drops,
+// the Client transparently redials the same URL rather than leaving callers
+// to notice and reconnect themselves.
+func DialTarget(wsURL string) (*Client, error) {
+	dial := func() (*websocket.Conn, error) {
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		return conn, err
+	}
+
+	conn, err := dial()
+
+	if err != nil {
+		return nil, err
+	}
+
+	client := &Client{
+		conn:     conn,
+		dial:     dial,
+		done:     make(chan struct{}),
+		pending:  make(map[uint64]pendingRequest),
+		subs:     make(map[string][]chan Event),
+		sessions: make(map[string]bool),
+	}
+
+	go client.read()
+
+	return client, nil
+}
+
+// Send issues a CDP command and blocks until a response is received. An
+// empty sessionID targets the browser itself; a non-empty sessionID targets
+// an attached session, as returned by the Target.attachToTarget command.
+func (client *Client) Send(sessionID string, method string, params interface{}) (json.RawMessage, error) {
+	id := atomic.AddUint64(&client.id, 1)
+	reply := make(chan cdpResponse, 1)
+
+	client.mutex.Lock()
+	client.pending[id] = pendingRequest{sessionID: sessionID, reply: reply}
+	client.mutex.Unlock()
+
+	request := cdpRequest{ID: id, SessionID: sessionID, Method: method, Params: params}
+
+	client.writeMutex.Lock()
+	err := client.getConn().WriteJSON(request)
+	client.writeMutex.Unlock()
+
+	if err != nil {
+		client.mutex.Lock()
+		delete(client.pending, id)
+		client.mutex.Unlock()
+
+		return nil, err
+	}
+
+	response := <-reply
+
+	if response.Error != nil {
+		return nil, fmt.Errorf("chromium: %s", response.Error.Message)
+	}
+
+	return response.Result, nil
+}
+
+// Subscribe returns a channel of events matching method. The channel is
+// closed once the Client has given up for good, as reported by Done; a
+// connection drop that reconnects successfully leaves it open.
+func (client *Client) Subscribe(method string) <-chan Event {
+	events := make(chan Event, 16)
+
+	client.mutex.Lock()
+	client.subs[method] = append(client.subs[method], events)
+	client.mutex.Unlock()
+
+	return events
+}
+
+// Attach opens a session on target via Target.attachToTarget, returning the
+// sessionID to pass as Send's sessionID to scope further commands to it.
+// The Client tracks the session's lifecycle: if the target detaches on its
+// own (e.g. it's closed or navigates away), any of the session's requests
+// still awaiting a reply are failed as if Detach had been called.
+func (client *Client) Attach(targetID string) (string, error) {
+	client.watchDetach.Do(client.watchDetachedFromTarget)
+
+	result, err := client.Send("", "Target.attachToTarget", map[string]interface{}{
+		"targetId": targetID,
+		"flatten":  true,
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	var attached struct {
+		SessionID string `json:"sessionId"`
+	}
+
+	if err := json.Unmarshal(result, &attached); err != nil {
+		return "", err
+	}
+
+	client.mutex.Lock()
+	client.sessions[attached.SessionID] = true
+	client.mutex.Unlock()
+
+	return attached.SessionID, nil
+}
+
+// Detach closes a session opened by Attach via Target.detachFromTarget and
+// fails any of its requests still awaiting a reply.
+func (client *Client) Detach(sessionID string) error {
+	_, err := client.Send(sessionID, "Target.detachFromTarget", map[string]interface{}{
+		"sessionId": sessionID,
+	})
+
+	client.forgetSession(sessionID, fmt.Errorf("chromium: session %s detached", sessionID))
+
+	return err
+}
+
+// watchDetachedFromTarget subscribes to Target.detachedFromTarget and
+// forgets whichever session it names, covering a detach the target itself
+// initiates rather than one driven by a Detach call.
+func (client *Client) watchDetachedFromTarget() {
+	events := client.Subscribe("Target.detachedFromTarget")
+
+	go func() {
+		for event := range events {
+			var detached struct {
+				SessionID string `json:"sessionId"`
+			}
+
+			if err := json.Unmarshal(event.Params, &detached); err != nil {
+				continue
+			}
+
+			client.forgetSession(detached.SessionID, fmt.Errorf("chromium: session %s detached", detached.SessionID))
+		}
+	}()
+}
+
+// forgetSession stops tracking sessionID and fails any of its requests
+// still awaiting a reply with err, since nothing will answer them once the
+// session is gone.
+func (client *Client) forgetSession(sessionID string, err error) {
+	client.mutex.Lock()
+	delete(client.sessions, sessionID)
+
+	var replies []chan cdpResponse
+
+	for id, p := range client.pending {
+		if p.sessionID == sessionID {
+			replies = append(replies, p.reply)
+			delete(client.pending, id)
+		}
+	}
+	client.mutex.Unlock()
+
+	for _, reply := range replies {
+		reply <- cdpResponse{Error: &cdpError{Message: err.Error()}}
+	}
+}
+
+// Close closes the underlying WebSocket connection. Unlike a connection
+// drop, a Close never triggers read's automatic reconnect.
+func (client *Client) Close() error {
+	client.mutex.Lock()
+	client.closing = true
+	conn := client.conn
+	client.mutex.Unlock()
+
+	return conn.Close()
+}
+
+// Done returns a channel that is closed once the Client has given up for
+// good: either Close was called, or the connection dropped and reconnecting
+// failed too many times in a row.
+func (client *Client) Done() <-chan struct{} {
+	return client.done
+}
+
+func (client *Client) getConn() *websocket.Conn {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	return client.conn
+}
+
+func (client *Client) read() {
+	for {
+		_, message, err := client.getConn().ReadMessage()
+
+		if err != nil {
+			// The connection dropped: nothing will ever answer the requests
+			// already in flight on it, so fail them immediately rather than
+			// leaving Send blocked on a reply that will never come.
+			client.failPending(err)
+
+			if client.reconnect() {
+				continue
+			}
+
+			client.closeSubscribers()
+			close(client.done)
+
+			return
+		}
+
+		var envelope struct {
+			ID uint64 `json:"id"`
+		}
+
+		if err := json.Unmarshal(message, &envelope); err != nil {
+			continue
+		}
+
+		if envelope.ID != 0 {
+			var response cdpResponse
+
+			if err := json.Unmarshal(message, &response); err != nil {
+				continue
+			}
+
+			client.mutex.Lock()
+			p, ok := client.pending[response.ID]
+			delete(client.pending, response.ID)
+			client.mutex.Unlock()
+
+			if ok {
+				p.reply <- response
+			}
+
+			continue
+		}
+
+		var event cdpEvent
+
+		if err := json.Unmarshal(message, &event); err != nil {
+			continue
+		}
+
+		client.mutex.Lock()
+		subs := append([]chan Event(nil), client.subs[event.Method]...)
+		client.mutex.Unlock()
+
+		for _, sub := range subs {
+			sub <- Event{SessionID: event.SessionID, Method: event.Method, Params: event.Params}
+		}
+	}
+}
+
+// reconnect redials client.dial with a backoff between attempts, swapping
+// in the new connection on success. It reports whether read should keep
+// going on the new connection.
+func (client *Client) reconnect() bool {
+	client.mutex.Lock()
+	closing := client.closing
+	dial := client.dial
+	client.mutex.Unlock()
+
+	if closing || dial == nil {
+		return false
+	}
+
+	backoff := 100 * time.Millisecond
+
+	for attempt := 0; attempt < maxReconnectAttempts; attempt++ {
+		time.Sleep(backoff)
+
+		conn, err := dial()
+
+		if err == nil {
+			client.mutex.Lock()
+			client.conn = conn
+			client.mutex.Unlock()
+
+			return true
+		}
+
+		backoff *= 2
+	}
+
+	return false
+}
+
+// failPending delivers err to every request currently awaiting a reply and
+// clears the pending set, since none of them will ever be answered on the
+// connection that just dropped.
+func (client *Client) failPending(err error) {
+	client.mutex.Lock()
+	pending := client.pending
+	client.pending = make(map[uint64]pendingRequest)
+	client.mutex.Unlock()
+
+	for _, p := range pending {
+		p.reply <- cdpResponse{Error: &cdpError{Message: err.Error()}}
+	}
+}
+
+func (client *Client) closeSubscribers() {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	for _, subs := range client.subs {
+		for _, sub := range subs {
+			close(sub)
+		}
+	}
+}
+
+func getJSON(url string, out interface{}) error {
+	response, err := http.Get(url)
+
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	return json.NewDecoder(response.Body).Decode(out)
+}
@@ -0,0 +1,167 @@
+package chromium_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	websocket "github.com/gorilla/websocket"
+	chromium "github.com/kasperisager/chromium"
+)
+
+// newControlledServer starts a WebSocket server that hands every decoded
+// request to the test over requests and the raw conn over conns, so the
+// test can script responses and events itself instead of a fixed echo.
+func newControlledServer(t *testing.T) (wsURL string, requests chan map[string]interface{}, conns chan *websocket.Conn) {
+	t.Helper()
+
+	requests = make(chan map[string]interface{}, 16)
+	conns = make(chan *websocket.Conn, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := echoUpgrader.Upgrade(w, r, nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		conns <- conn
+
+		for {
+			var request map[string]interface{}
+
+			if err := conn.ReadJSON(&request); err != nil {
+				close(requests)
+				return
+			}
+
+			requests <- request
+		}
+	}))
+
+	t.Cleanup(server.Close)
+
+	return "ws" + strings.TrimPrefix(server.URL, "http"), requests, conns
+}
+
+// TestClientAttachDetach exercises a full Attach/Detach round trip against
+// a server that plays along with Target.attachToTarget and
+// Target.detachFromTarget.
+func TestClientAttachDetach(t *testing.T) {
+	wsURL, requests, conns := newControlledServer(t)
+
+	client, err := chromium.DialTarget(wsURL)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer client.Close()
+
+	conn := <-conns
+
+	go func() {
+		for request := range requests {
+			switch request["method"] {
+			case "Target.attachToTarget":
+				conn.WriteJSON(map[string]interface{}{
+					"id":     request["id"],
+					"result": map[string]interface{}{"sessionId": "SESSION1"},
+				})
+			case "Target.detachFromTarget":
+				conn.WriteJSON(map[string]interface{}{
+					"id":     request["id"],
+					"result": map[string]interface{}{},
+				})
+			}
+		}
+	}()
+
+	sessionID, err := client.Attach("TARGET1")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sessionID != "SESSION1" {
+		t.Fatalf("Attach returned sessionID %q, want %q", sessionID, "SESSION1")
+	}
+
+	if err := client.Detach(sessionID); err != nil {
+		t.Fatalf("Detach: %v", err)
+	}
+}
+
+// TestClientDetachedFromTargetUnblocksPending simulates the target
+// detaching on its own, mid-command, and checks that the Send blocked on
+// that session is failed rather than left hanging forever.
+func TestClientDetachedFromTargetUnblocksPending(t *testing.T) {
+	wsURL, requests, conns := newControlledServer(t)
+
+	client, err := chromium.DialTarget(wsURL)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer client.Close()
+
+	conn := <-conns
+
+	// writeMu serializes writes to conn: both the request-handling
+	// goroutine below and the detach event sent further down write to the
+	// same server-side connection.
+	var writeMu sync.Mutex
+
+	go func() {
+		for request := range requests {
+			if request["method"] == "Target.attachToTarget" {
+				writeMu.Lock()
+				conn.WriteJSON(map[string]interface{}{
+					"id":     request["id"],
+					"result": map[string]interface{}{"sessionId": "SESSION1"},
+				})
+				writeMu.Unlock()
+			}
+
+			// Anything else (the in-flight command below) is deliberately
+			// left unanswered, as if the target had gone away mid-command.
+		}
+	}()
+
+	sessionID, err := client.Attach("TARGET1")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+
+	go func() {
+		_, err := client.Send(sessionID, "Some.command", nil)
+		done <- err
+	}()
+
+	// Give Send a moment to register itself as pending before the target
+	// detaches out from under it.
+	time.Sleep(50 * time.Millisecond)
+
+	writeMu.Lock()
+	conn.WriteJSON(map[string]interface{}{
+		"method": "Target.detachedFromTarget",
+		"params": map[string]interface{}{"sessionId": sessionID},
+	})
+	writeMu.Unlock()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("expected Send to fail once its session detached")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Send did not unblock after Target.detachedFromTarget")
+	}
+}
@@ -0,0 +1,96 @@
+package chromium_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	websocket "github.com/gorilla/websocket"
+	chromium "github.com/kasperisager/chromium"
+)
+
+var echoUpgrader = websocket.Upgrader{}
+
+// newEchoServer starts a WebSocket server that answers every CDP request
+// with an empty, successful result carrying the same id, so Client.Send
+// has something to talk to without a real Chromium process.
+func newEchoServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := echoUpgrader.Upgrade(w, r, nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		go func() {
+			for {
+				var request struct {
+					ID uint64 `json:"id"`
+				}
+
+				if err := conn.ReadJSON(&request); err != nil {
+					return
+				}
+
+				conn.WriteJSON(map[string]interface{}{
+					"id":     request.ID,
+					"result": json.RawMessage("{}"),
+				})
+			}
+		}()
+	}))
+
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func dialEchoServer(t *testing.T) *chromium.Client {
+	t.Helper()
+
+	server := newEchoServer(t)
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	client, err := chromium.DialTarget(wsURL)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { client.Close() })
+
+	return client
+}
+
+// TestClientSendConcurrent makes good on Client's doc comment promise that
+// it's "safe for concurrent use": run with -race, this reliably crashed
+// with "concurrent write to websocket connection" before Send serialized
+// writes to the connection with their own mutex.
+func TestClientSendConcurrent(t *testing.T) {
+	client := dialEchoServer(t)
+
+	const goroutines = 20
+	const perGoroutine = 50
+
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < perGoroutine; j++ {
+				if _, err := client.Send("", "Some.method", nil); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
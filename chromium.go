@@ -1,15 +1,16 @@
 package chromium
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net"
 	"os"
 	"os/exec"
-	"strconv"
+	"runtime"
+	"syscall"
 	"time"
-
-	watcher "gopkg.in/radovskyb/watcher.v1"
 )
 
 var (
@@ -23,26 +24,72 @@ type Chromium interface {
 	// Start the Chromium process without waiting for it to finish. Start returns
 	// only when the remote debugging endpoint is ready to serve clients. Start is
 	// idempotent and invoking it on an already running process has no effect.
+	// Start is equivalent to StartContext with context.Background.
 	Start() (uint16, error)
 
+	// StartContext is like Start but aborts, returning an error that wraps
+	// ctx.Err(), if ctx is cancelled or its deadline passes before the remote debugging endpoint
+	// becomes ready. This bounds the otherwise unbounded wait for
+	// DevToolsActivePort to appear.
+	StartContext(ctx context.Context) (uint16, error)
+
 	// Stop the Chromium process. Stop is idempotent and invoking it on an already
-	// stopped process has no effect.
+	// stopped process has no effect. Stop is equivalent to StopContext with
+	// context.Background.
 	Stop() error
 
+	// StopContext asks the process to close gracefully (via CDP Browser.close
+	// where available, or SIGTERM otherwise), waits up to GracePeriod or until
+	// ctx is done, and then forcibly kills the process if it is still running.
+	StopContext(ctx context.Context) error
+
 	// Wait for the Chromium process to finish. Wait is idempotent and invoking
-	// it on an already stopped process has no effect.
+	// it on an already stopped process has no effect. Wait is equivalent to
+	// WaitContext with context.Background.
 	Wait() error
 
+	// WaitContext is like Wait but returns ctx.Err() if ctx is cancelled or its
+	// deadline passes before the process finishes on its own.
+	WaitContext(ctx context.Context) error
+
 	// Read-only channel of errors emitted by the Chromium process.
 	Errors() <-chan error
+
+	// Client returns a Client connected to the process's remote debugging
+	// endpoint over the Chrome DevTools Protocol. Start must have completed
+	// successfully first. The Client is cached and reused on subsequent calls.
+	Client() (*Client, error)
+
+	// WebSocketDebuggerURL returns the browser's CDP WebSocket endpoint, as
+	// reported by Chromium itself in DevToolsActivePort or, for a remote
+	// Chromium, the wsEndpoint passed to Connect. For a Chromium created with
+	// New, it is empty until Start has completed successfully.
+	WebSocketDebuggerURL() string
 }
 
 type chromium struct {
 	path  string
 	data  string
+	addr  string
+	port  uint16
+	wsURL string
 	flags []Flag
 	errs  chan error
 	cmd   *exec.Cmd
+
+	// remote is true for a Chromium obtained through Connect, in which case
+	// there is no local process to launch, stop, or wait for.
+	remote bool
+
+	// grace is how long StopContext waits for a graceful exit before
+	// resorting to Process.Kill, as set by the GracePeriod option.
+	grace time.Duration
+
+	// logHandler, if set via WithLogHandler, receives every Entry parsed from
+	// the process's logging, regardless of Level.
+	logHandler func(Entry)
+
+	client *Client
 }
 
 // New returns a new Chromium process using the flags. A complete list of
@@ -66,20 +113,91 @@ func (chromium *chromium) Flag(key string) (interface{}, bool) {
 	return nil, false
 }
 
+// allowPort merges port into the explicitly-allowed-ports flag, adding the
+// flag if it isn't already present.
+func (chromium *chromium) allowPort(port uint16) {
+	for i, flag := range chromium.flags {
+		if flag.Key == "explicitly-allowed-ports" {
+			if ports, ok := flag.Value.([]uint16); ok {
+				chromium.flags[i].Value = append(ports, port)
+				return
+			}
+		}
+	}
+
+	chromium.flags = append(chromium.flags, ExplicitlyAllowedPorts(port))
+}
+
 func (chromium *chromium) Errors() <-chan error {
 	return chromium.errs
 }
 
+func (chromium *chromium) WebSocketDebuggerURL() string {
+	return chromium.wsURL
+}
+
+func (chromium *chromium) Client() (*Client, error) {
+	if chromium.client != nil {
+		return chromium.client, nil
+	}
+
+	if chromium.port == 0 {
+		return nil, ErrNoPortAssigned
+	}
+
+	client, err := Dial(chromium.addr, chromium.port)
+
+	if err != nil {
+		return nil, err
+	}
+
+	chromium.client = client
+
+	return chromium.client, nil
+}
+
 func (chromium *chromium) Start() (uint16, error) {
+	return chromium.StartContext(context.Background())
+}
+
+func (chromium *chromium) StartContext(ctx context.Context) (uint16, error) {
+	if chromium.remote {
+		// A remote session is already attached by the time it reaches here
+		// (Connect dials its Client up front), regardless of whether
+		// wsEndpoint's URL had an explicit port to parse chromium.port from.
+		if chromium.client == nil {
+			return 0, ErrNoPortAssigned
+		}
+
+		return chromium.port, nil
+	}
+
 	if chromium.cmd != nil {
 		return 0, ErrProcessRunning
 	}
 
-	chromium.flags = append(chromium.flags,
-		Flag{"headless", true},
-		Flag{"disable-gpu", true},
-		Flag{"no-sandbox", true},
-	)
+	chromium.resolveOptions()
+
+	if _, has := chromium.Flag("headless"); !has {
+		chromium.flags = append(chromium.flags, Flag{"headless", true})
+	}
+
+	if _, has := chromium.Flag("disable-gpu"); !has {
+		chromium.flags = append(chromium.flags, Flag{"disable-gpu", true})
+	}
+
+	if _, has := chromium.Flag("no-sandbox"); !has {
+		if sandbox, has := chromium.Flag(optionSandbox); has {
+			if !sandbox.(bool) {
+				chromium.flags = append(chromium.flags, Flag{"no-sandbox", true})
+			}
+		} else if runtime.GOOS == "linux" && os.Geteuid() == 0 {
+			// The sandbox refuses to initialize when running as root on Linux, so
+			// mirror the policy httpx and similar tools use: only disable it when
+			// we know it would otherwise prevent Chromium from starting at all.
+			chromium.flags = append(chromium.flags, Flag{"no-sandbox", true})
+		}
+	}
 
 	if data, has := chromium.Flag("user-data-dir"); has {
 		chromium.data = data.(string)
@@ -94,7 +212,10 @@ func (chromium *chromium) Start() (uint16, error) {
 		chromium.data = data
 	}
 
-	if _, has := chromium.Flag("remote-debugging-address"); !has {
+	if address, has := chromium.Flag("remote-debugging-address"); has {
+		chromium.addr = fmt.Sprintf("%v", address)
+	} else {
+		chromium.addr = "127.0.0.1"
 		chromium.flags = append(chromium.flags, Flag{"remote-debugging-address", net.IPv4(127, 0, 0, 1)})
 	}
 
@@ -102,10 +223,22 @@ func (chromium *chromium) Start() (uint16, error) {
 		chromium.flags = append(chromium.flags, Flag{"remote-debugging-port", 0})
 	}
 
-	flags := make([]string, len(chromium.flags))
+	if port, has := chromium.Flag("remote-debugging-port"); has {
+		if p, ok := port.(uint16); ok && isRestrictedPort(p) {
+			chromium.allowPort(p)
+		}
+	}
 
-	for i, flag := range chromium.flags {
-		flags[i] = flag.String()
+	var flags []string
+
+	for _, flag := range chromium.flags {
+		if isOption(flag) {
+			continue
+		}
+
+		if s := flag.String(); s != "" {
+			flags = append(flags, s)
+		}
 	}
 
 	chromium.cmd = exec.Command(chromium.path, flags...)
@@ -116,76 +249,131 @@ func (chromium *chromium) Start() (uint16, error) {
 		return 0, err
 	}
 
-	go Scan(stderr, chromium.errs)
-
-	poller := watcher.New()
+	go Scan(stderr, chromium.errs, chromium.logHandler)
 
-	defer poller.Close()
-
-	if err := poller.Add(chromium.data); err != nil {
+	if err := chromium.cmd.Start(); err != nil {
 		return 0, err
 	}
 
-	go poller.Start(20 * time.Millisecond)
+	found, err := chromium.awaitPort(ctx)
 
-	if err := chromium.cmd.Start(); err != nil {
-		return 0, err
+	if err != nil {
+		return 0, fmt.Errorf("chromium: waiting for remote debugging endpoint: %w", err)
 	}
 
-	for {
-		select {
-		case event := <-poller.Event:
-			if event.Name() != "DevToolsActivePort" {
-				continue
-			}
+	chromium.port = found.port
+	chromium.wsURL = found.ws
 
-			file, err := ioutil.ReadFile(event.Path)
+	return chromium.port, nil
+}
 
-			if err != nil {
-				return 0, err
-			}
+func (chromium *chromium) Stop() error {
+	return chromium.StopContext(context.Background())
+}
 
-			port, err := strconv.ParseUint(string(file), 10, 16)
+func (chromium *chromium) StopContext(ctx context.Context) error {
+	if chromium.remote {
+		if chromium.client == nil {
+			return ErrProcessNotRunning
+		}
 
-			if err != nil {
-				return 0, err
-			}
+		defer chromium.Cleanup()
 
-			return uint16(port), nil
-		case err := <-poller.Error:
-			return 0, err
-		case err := <-chromium.errs:
-			return 0, err
-		}
+		return chromium.client.Close()
 	}
-}
 
-func (chromium *chromium) Stop() error {
 	if chromium.cmd == nil {
 		return ErrProcessNotRunning
 	}
 
 	defer chromium.Cleanup()
 
-	if err := chromium.cmd.Process.Kill(); err != nil {
-		return err
+	grace := chromium.grace
+
+	if grace == 0 {
+		grace = defaultGracePeriod
 	}
 
-	return nil
+	// Captured once up front: Cleanup (deferred above) nils out chromium.cmd
+	// as soon as this method returns, which would race with the goroutine
+	// below and the Kill call past the select if they read chromium.cmd
+	// directly instead of this local.
+	cmd := chromium.cmd
+
+	exited := make(chan struct{})
+
+	go func() {
+		defer close(exited)
+
+		if client, err := chromium.Client(); err == nil {
+			client.Send("", "Browser.close", nil)
+		} else {
+			cmd.Process.Signal(syscall.SIGTERM)
+		}
+
+		cmd.Wait()
+	}()
+
+	timeout, cancel := context.WithTimeout(ctx, grace)
+	defer cancel()
+
+	select {
+	case <-exited:
+		return nil
+	case <-timeout.Done():
+		return cmd.Process.Kill()
+	}
 }
 
 func (chromium *chromium) Wait() error {
+	return chromium.WaitContext(context.Background())
+}
+
+func (chromium *chromium) WaitContext(ctx context.Context) error {
+	if chromium.remote {
+		if chromium.client == nil {
+			return ErrProcessNotRunning
+		}
+
+		select {
+		case <-chromium.client.Done():
+			chromium.Cleanup()
+			return nil
+		case <-ctx.Done():
+			// The remote session is still live; only Cleanup once it has
+			// actually finished, or a caller polling with a deadline tears
+			// down a Client and user-data-dir that a still-running session
+			// (and a later Stop) still need.
+			return ctx.Err()
+		}
+	}
+
 	if chromium.cmd == nil {
 		return ErrProcessNotRunning
 	}
 
-	defer chromium.Cleanup()
+	// Captured once up front, same as StopContext: Cleanup (called below)
+	// nils out chromium.cmd once the process has actually finished, which
+	// would race with the goroutine below if it read chromium.cmd directly.
+	cmd := chromium.cmd
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- cmd.Wait()
+	}()
 
-	if err := chromium.cmd.Wait(); err != nil {
+	select {
+	case err := <-done:
+		chromium.Cleanup()
 		return err
+	case <-ctx.Done():
+		// The process is still running; only Cleanup once it has actually
+		// finished, or a caller polling with a deadline tears down the temp
+		// user-data-dir and cached Client out from under the still-running
+		// process, and a later Stop finds nothing to stop.
+		return ctx.Err()
 	}
-
-	return nil
 }
 
 func (chromium *chromium) Cleanup() {
@@ -193,6 +381,13 @@ func (chromium *chromium) Cleanup() {
 		os.RemoveAll(chromium.data)
 	}
 
+	if chromium.client != nil {
+		chromium.client.Close()
+		chromium.client = nil
+	}
+
 	chromium.cmd = nil
 	chromium.data = ""
+	chromium.port = 0
+	chromium.wsURL = ""
 }
@@ -11,7 +11,7 @@ func ExampleNew() {
 }
 
 func ExampleNew_flags() {
-	chromium.New("google-chrome", chromium.Port(9222), chromium.WindowSize(1920, 1080))
+	chromium.New("google-chrome", chromium.Port(9222), chromium.Size(1920, 1080))
 }
 
 func ExampleNew_ephemeral() {
@@ -0,0 +1,168 @@
+package chromium
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+)
+
+// Connect attaches to a Chromium instance that is already running remotely,
+// such as one running in a container, on another host, or launched outside
+// of this package entirely. wsEndpoint is the browser's WebSocket debugger
+// URL, as printed by Chrome on startup or returned by Version. flags
+// accepts the same Flag values as New, but only WithLogHandler has an
+// effect: genuine command-line flags, Sandbox and GracePeriod are ignored
+// since there is no process to launch, and Stop only closes the connection.
+//
+// The returned Chromium satisfies the same interface as one created with
+// New, but Start and Stop no-op on the process itself: there is no local
+// process to launch or kill. Errors is instead sourced from the CDP
+// Log.entryAdded and Runtime.consoleAPICalled events of the remote browser.
+func Connect(wsEndpoint string, flags ...Flag) (Chromium, error) {
+	client, err := DialTarget(wsEndpoint)
+
+	if err != nil {
+		return nil, err
+	}
+
+	remote := &chromium{
+		remote: true,
+		client: client,
+		flags:  flags,
+		errs:   make(chan error, 1),
+		wsURL:  wsEndpoint,
+	}
+
+	remote.resolveOptions()
+
+	if u, err := url.Parse(wsEndpoint); err == nil {
+		remote.addr = u.Hostname()
+
+		if port, err := strconv.ParseUint(u.Port(), 10, 16); err == nil {
+			remote.port = uint16(port)
+		}
+	}
+
+	if err := remote.attachLogging(); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return remote, nil
+}
+
+// attachLogging enables the Log and Runtime domains on the remote browser
+// and forwards the entries and console messages they report to errs, taking
+// the place of the stderr scanning New-created Chromium values rely on.
+func (chromium *chromium) attachLogging() error {
+	if _, err := chromium.client.Send("", "Log.enable", nil); err != nil {
+		return err
+	}
+
+	if _, err := chromium.client.Send("", "Runtime.enable", nil); err != nil {
+		return err
+	}
+
+	go chromium.forwardLogEntries(chromium.client.Subscribe("Log.entryAdded"))
+	go chromium.forwardConsoleMessages(chromium.client.Subscribe("Runtime.consoleAPICalled"))
+
+	return nil
+}
+
+type logEntryAddedParams struct {
+	Entry struct {
+		Source     string `json:"source"`
+		Level      string `json:"level"`
+		Text       string `json:"text"`
+		URL        string `json:"url"`
+		LineNumber int    `json:"lineNumber"`
+	} `json:"entry"`
+}
+
+type consoleAPICalledParams struct {
+	Type string `json:"type"`
+	Args []struct {
+		Description string          `json:"description"`
+		Value       json.RawMessage `json:"value"`
+	} `json:"args"`
+}
+
+func (chromium *chromium) forwardLogEntries(events <-chan Event) {
+	for event := range events {
+		var params logEntryAddedParams
+
+		if err := json.Unmarshal(event.Params, &params); err != nil {
+			continue
+		}
+
+		entry := Entry{
+			Level:   parseCDPLogLevel(params.Entry.Level),
+			File:    params.Entry.URL,
+			Line:    params.Entry.LineNumber,
+			Message: params.Entry.Text,
+		}
+
+		chromium.emit(entry)
+	}
+}
+
+func (chromium *chromium) forwardConsoleMessages(events <-chan Event) {
+	for event := range events {
+		var params consoleAPICalledParams
+
+		if err := json.Unmarshal(event.Params, &params); err != nil {
+			continue
+		}
+
+		msg := params.Type
+
+		for _, arg := range params.Args {
+			if arg.Description != "" {
+				msg += " " + arg.Description
+			} else {
+				msg += " " + string(arg.Value)
+			}
+		}
+
+		level := Info
+
+		if params.Type == "error" {
+			level = Error
+		} else if params.Type == "warning" {
+			level = Warning
+		}
+
+		chromium.emit(Entry{Level: level, Message: msg})
+	}
+}
+
+// emit dispatches entry to the log handler, if any, and forwards it to errs
+// when it's at Error level or above, mirroring the default Scan behaviour
+// stderr-driven Chromium values get.
+func (chromium *chromium) emit(entry Entry) {
+	if chromium.logHandler != nil {
+		chromium.logHandler(entry)
+	}
+
+	if entry.Level == Error || entry.Level == Fatal {
+		chromium.errs <- &entry
+	}
+}
+
+// parseCDPLogLevel maps the lowercase level strings used by Log.entryAdded
+// (https://chromedevtools.github.io/devtools-protocol/tot/Log/#type-LogEntry)
+// onto this package's Level.
+func parseCDPLogLevel(level string) Level {
+	switch level {
+	case "verbose":
+		return Verbose
+	case "info":
+		return Info
+	case "warning":
+		return Warning
+	case "error":
+		return Error
+	default:
+		return Raw
+	}
+}
@@ -0,0 +1,23 @@
+package chromium
+
+import "testing"
+
+func TestParseCDPLogLevel(t *testing.T) {
+	cases := []struct {
+		level string
+		want  Level
+	}{
+		{"verbose", Verbose},
+		{"info", Info},
+		{"warning", Warning},
+		{"error", Error},
+		{"something-unknown", Raw},
+		{"", Raw},
+	}
+
+	for _, c := range cases {
+		if got := parseCDPLogLevel(c.level); got != c.want {
+			t.Errorf("parseCDPLogLevel(%q) = %v, want %v", c.level, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package chromium_test
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	websocket "github.com/gorilla/websocket"
+	chromium "github.com/kasperisager/chromium"
+)
+
+// TestConnect exercises Connect end to end against a fake remote debugging
+// endpoint: it answers the Log.enable/Runtime.enable calls attachLogging
+// makes, then plays back a Log.entryAdded and a Runtime.consoleAPICalled
+// event to check that both are delivered to the log handler and that only
+// the Error-level one reaches Errors(), mirroring Scan's behaviour for a
+// locally started Chromium.
+func TestConnect(t *testing.T) {
+	wsURL, requests, conns := newControlledServer(t)
+
+	var writeMu sync.Mutex
+	connReady := make(chan *websocket.Conn, 1)
+
+	go func() {
+		conn := <-conns
+		connReady <- conn
+
+		for request := range requests {
+			switch request["method"] {
+			case "Log.enable", "Runtime.enable":
+				writeMu.Lock()
+				conn.WriteJSON(map[string]interface{}{
+					"id":     request["id"],
+					"result": map[string]interface{}{},
+				})
+				writeMu.Unlock()
+			}
+		}
+	}()
+
+	var (
+		mu      sync.Mutex
+		entries []chromium.Entry
+	)
+
+	remote, err := chromium.Connect(wsURL, chromium.WithLogHandler(func(entry chromium.Entry) {
+		mu.Lock()
+		entries = append(entries, entry)
+		mu.Unlock()
+	}))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer remote.Stop()
+
+	conn := <-connReady
+
+	writeMu.Lock()
+	conn.WriteJSON(map[string]interface{}{
+		"method": "Log.entryAdded",
+		"params": map[string]interface{}{
+			"entry": map[string]interface{}{
+				"source":     "console-api",
+				"level":      "error",
+				"text":       "boom",
+				"url":        "file.js",
+				"lineNumber": 42,
+			},
+		},
+	})
+	conn.WriteJSON(map[string]interface{}{
+		"method": "Runtime.consoleAPICalled",
+		"params": map[string]interface{}{
+			"type": "warning",
+			"args": []map[string]interface{}{
+				{"description": "careful"},
+			},
+		},
+	})
+	writeMu.Unlock()
+
+	deadline := time.After(2 * time.Second)
+
+	for {
+		mu.Lock()
+		got := len(entries)
+		mu.Unlock()
+
+		if got >= 2 {
+			break
+		}
+
+		select {
+		case <-deadline:
+			t.Fatalf("log handler saw %d entries, want 2", got)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// The two events are forwarded by separate goroutines, so they can reach
+	// the log handler in either order; sort the Error entry first.
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Level > entries[j].Level })
+
+	if entries[0].Level != chromium.Error || entries[0].Message != "boom" || entries[0].File != "file.js" || entries[0].Line != 42 {
+		t.Fatalf("unexpected Log.entryAdded entry: %+v", entries[0])
+	}
+
+	if entries[1].Level != chromium.Warning || entries[1].Message != "warning careful" {
+		t.Fatalf("unexpected Runtime.consoleAPICalled entry: %+v", entries[1])
+	}
+
+	select {
+	case err := <-remote.Errors():
+		entry, ok := err.(*chromium.Entry)
+
+		if !ok || entry.Message != "boom" {
+			t.Fatalf("Errors() delivered %v, want the boom entry", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Errors() never delivered the Error-level entry")
+	}
+
+	select {
+	case err := <-remote.Errors():
+		t.Fatalf("Errors() unexpectedly delivered a second entry: %v", err)
+	default:
+	}
+}
@@ -0,0 +1,187 @@
+package chromium
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	fsnotify "github.com/fsnotify/fsnotify"
+)
+
+// dialInterval is how often the TCP-dial fallback in awaitPort probes the
+// requested debugging port while waiting for fsnotify to report the
+// DevToolsActivePort file.
+const dialInterval = 20 * time.Millisecond
+
+type discovery struct {
+	port uint16
+	ws   string
+}
+
+// awaitPort waits for the just-started process's remote debugging endpoint
+// to come up, returning its port and WebSocketDebuggerURL.
+//
+// It watches user-data-dir for DevToolsActivePort with fsnotify, which is
+// edge-triggered and so doesn't repeatedly rescan the (potentially large)
+// profile directory the way polling it would. As a fallback for platforms
+// where inotify is unreliable, it races that watch against a TCP dial loop
+// to the requested debugging port, confirmed by querying /json/version to
+// make sure it's really Chromium answering. The dial loop only runs when a
+// fixed port was requested; an OS-assigned port (0) can only be discovered
+// through the file.
+func (chromium *chromium) awaitPort(ctx context.Context) (discovery, error) {
+	notify, err := fsnotify.NewWatcher()
+
+	if err != nil {
+		return discovery{}, err
+	}
+
+	defer notify.Close()
+
+	if err := notify.Add(chromium.data); err != nil {
+		return discovery{}, err
+	}
+
+	portFile := filepath.Join(chromium.data, "DevToolsActivePort")
+
+	// awaitPort is only called once the process is already started, so
+	// DevToolsActivePort may have been written before the watch above was
+	// armed, in which case fsnotify will never report it. Check for it
+	// directly before falling back to watching for it to appear.
+	if found, err := readDevToolsActivePort(portFile, chromium.addr); err == nil {
+		return found, nil
+	}
+
+	// Scope the dial loop to this call: whichever path wins the race below,
+	// cancel here so the other keeps ticking for one iteration at most
+	// instead of leaking a goroutine that dials the debugging port forever.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var dialed <-chan discovery
+
+	if port, has := chromium.Flag("remote-debugging-port"); has {
+		if p, ok := port.(uint16); ok && p != 0 {
+			dialed = chromium.dialPort(ctx, p)
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return discovery{}, ctx.Err()
+		case event, ok := <-notify.Events:
+			if !ok {
+				continue
+			}
+
+			if filepath.Base(event.Name) != "DevToolsActivePort" {
+				continue
+			}
+
+			if event.Op&(fsnotify.Create|fsnotify.Write) == 0 {
+				continue
+			}
+
+			found, err := readDevToolsActivePort(portFile, chromium.addr)
+
+			if err != nil {
+				continue
+			}
+
+			return found, nil
+		case err, ok := <-notify.Errors:
+			if !ok {
+				continue
+			}
+
+			return discovery{}, err
+		case found, ok := <-dialed:
+			if !ok {
+				dialed = nil
+				continue
+			}
+
+			return found, nil
+		case err := <-chromium.errs:
+			return discovery{}, err
+		}
+	}
+}
+
+// dialPort repeatedly TCP-dials addr:port until something answers and its
+// /json/version response confirms it's Chromium, then reports the
+// browser's WebSocketDebuggerURL on the returned channel. The channel is
+// closed without a value if ctx is done first.
+func (chromium *chromium) dialPort(ctx context.Context, port uint16) <-chan discovery {
+	found := make(chan discovery, 1)
+
+	go func() {
+		defer close(found)
+
+		ticker := time.NewTicker(dialInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				conn, err := net.DialTimeout("tcp", net.JoinHostPort(chromium.addr, strconv.Itoa(int(port))), dialInterval)
+
+				if err != nil {
+					continue
+				}
+
+				conn.Close()
+
+				target, err := Version(chromium.addr, port)
+
+				if err != nil {
+					continue
+				}
+
+				found <- discovery{port: port, ws: target.WebSocketDebuggerURL}
+
+				return
+			}
+		}
+	}()
+
+	return found
+}
+
+// readDevToolsActivePort reads the port Chromium chose for its remote
+// debugging endpoint off the DevToolsActivePort file. Chromium writes two
+// lines to this file: the port number, and the browser's WebSocket
+// debugger path (e.g. /devtools/browser/<uuid>), which readDevToolsActivePort
+// turns into a full ws:// URL.
+func readDevToolsActivePort(path string, addr string) (discovery, error) {
+	contents, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return discovery{}, err
+	}
+
+	lines := strings.SplitN(strings.TrimSpace(string(contents)), "\n", 2)
+
+	port, err := strconv.ParseUint(strings.TrimSpace(lines[0]), 10, 16)
+
+	if err != nil {
+		return discovery{}, err
+	}
+
+	found := discovery{port: uint16(port)}
+
+	if len(lines) > 1 {
+		host := net.JoinHostPort(addr, strings.TrimSpace(lines[0]))
+		found.ws = fmt.Sprintf("ws://%s/%s", host, strings.TrimPrefix(strings.TrimSpace(lines[1]), "/"))
+	}
+
+	return found, nil
+}
@@ -0,0 +1,117 @@
+package chromium
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+var (
+	// https://support.google.com/chrome/a/answer/6271282
+	logFormat = regexp.MustCompile(`\[.*?:(INFO|WARNING|ERROR|FATAL|VERBOSE\d*):(.+)\((\d+)\)\]\s*(.*)`)
+)
+
+// Level is the severity of a log Entry, as reported by Chromium itself or
+// assigned by Scan when a line couldn't be parsed.
+type Level int
+
+const (
+	// Raw is assigned to lines Scan could not match against logFormat. The
+	// entry's Message holds the line verbatim.
+	Raw Level = iota
+	Verbose
+	Info
+	Warning
+	Error
+	Fatal
+)
+
+func (level Level) String() string {
+	switch level {
+	case Verbose:
+		return "VERBOSE"
+	case Info:
+		return "INFO"
+	case Warning:
+		return "WARNING"
+	case Error:
+		return "ERROR"
+	case Fatal:
+		return "FATAL"
+	default:
+		return "RAW"
+	}
+}
+
+// Entry is a single parsed line of Chromium's own logging, read off its
+// stderr stream by Scan.
+type Entry struct {
+	Level   Level
+	File    string
+	Line    int
+	Message string
+}
+
+func (entry *Entry) Error() string {
+	return "chromium: " + entry.Message
+}
+
+func parseLevel(token string) Level {
+	switch {
+	case token == "INFO":
+		return Info
+	case token == "WARNING":
+		return Warning
+	case token == "ERROR":
+		return Error
+	case token == "FATAL":
+		return Fatal
+	case len(token) >= len("VERBOSE") && token[:len("VERBOSE")] == "VERBOSE":
+		return Verbose
+	default:
+		return Raw
+	}
+}
+
+// Scan reads Chromium's stderr line by line, parsing each into an Entry and
+// sending the ones at Error level or above to errs. handle, if non-nil, is
+// additionally called with every Entry regardless of level, letting callers
+// observe INFO/WARNING/VERBOSE output and raw, unparseable lines via
+// WithLogHandler.
+func Scan(in io.Reader, errs chan<- error, handle func(Entry)) {
+	scanner := bufio.NewScanner(in)
+
+	for scanner.Scan() {
+		entry := parseEntry(scanner.Text())
+
+		if handle != nil {
+			handle(entry)
+		}
+
+		if entry.Level == Error || entry.Level == Fatal {
+			errs <- &entry
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		errs <- err
+	}
+}
+
+func parseEntry(text string) Entry {
+	parts := logFormat.FindStringSubmatch(text)
+
+	if parts == nil {
+		return Entry{Level: Raw, Message: text}
+	}
+
+	line, _ := strconv.ParseInt(parts[3], 10, 32)
+
+	return Entry{
+		Level:   parseLevel(parts[1]),
+		File:    parts[2],
+		Line:    int(line),
+		Message: parts[4],
+	}
+}
@@ -0,0 +1,104 @@
+package chromium
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseEntry(t *testing.T) {
+	cases := []struct {
+		name string
+		line string
+		want Entry
+	}{
+		{
+			name: "info",
+			line: "[0101/120000.000000:INFO:foo.cc(42)] starting up",
+			want: Entry{Level: Info, File: "foo.cc", Line: 42, Message: "starting up"},
+		},
+		{
+			name: "warning",
+			line: "[0101/120000.000000:WARNING:bar.cc(7)] something odd",
+			want: Entry{Level: Warning, File: "bar.cc", Line: 7, Message: "something odd"},
+		},
+		{
+			name: "error",
+			line: "[0101/120000.000000:ERROR:baz.cc(13)] it broke",
+			want: Entry{Level: Error, File: "baz.cc", Line: 13, Message: "it broke"},
+		},
+		{
+			name: "fatal",
+			line: "[0101/120000.000000:FATAL:qux.cc(99)] unrecoverable",
+			want: Entry{Level: Fatal, File: "qux.cc", Line: 99, Message: "unrecoverable"},
+		},
+		{
+			name: "verbose with numbered level",
+			line: "[0101/120000.000000:VERBOSE1:quux.cc(1)] chatter",
+			want: Entry{Level: Verbose, File: "quux.cc", Line: 1, Message: "chatter"},
+		},
+		{
+			name: "unmatched line",
+			line: "not a chromium log line at all",
+			want: Entry{Level: Raw, Message: "not a chromium log line at all"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := parseEntry(c.line)
+
+			if got != c.want {
+				t.Fatalf("parseEntry(%q) = %+v, want %+v", c.line, got, c.want)
+			}
+		})
+	}
+}
+
+// TestScanOnlyForwardsErrorAndAbove makes good on Scan's doc comment: handle
+// sees every line regardless of level, but only ERROR and FATAL lines are
+// sent to errs.
+func TestScanOnlyForwardsErrorAndAbove(t *testing.T) {
+	input := strings.Join([]string{
+		"[0101/120000.000000:INFO:foo.cc(1)] info line",
+		"[0101/120000.000000:WARNING:foo.cc(2)] warning line",
+		"[0101/120000.000000:ERROR:foo.cc(3)] error line",
+		"[0101/120000.000000:FATAL:foo.cc(4)] fatal line",
+		"not parseable at all",
+	}, "\n")
+
+	errs := make(chan error, 10)
+
+	var handled []Entry
+
+	Scan(strings.NewReader(input), errs, func(entry Entry) {
+		handled = append(handled, entry)
+	})
+
+	if len(handled) != 5 {
+		t.Fatalf("handle was called %d times, want 5", len(handled))
+	}
+
+	close(errs)
+
+	var forwarded []error
+
+	for err := range errs {
+		forwarded = append(forwarded, err)
+	}
+
+	if len(forwarded) != 2 {
+		t.Fatalf("errs received %d entries, want 2 (ERROR and FATAL only): %v", len(forwarded), forwarded)
+	}
+
+	for _, err := range forwarded {
+		entry, ok := err.(*Entry)
+
+		if !ok {
+			t.Fatalf("errs entry %v is not an *Entry", err)
+		}
+
+		if entry.Level != Error && entry.Level != Fatal {
+			t.Fatalf("errs forwarded a %v entry, want only Error/Fatal", entry.Level)
+		}
+	}
+}
@@ -3,6 +3,8 @@ package chromium
 import (
 	"fmt"
 	"net"
+	"strconv"
+	"strings"
 )
 
 type Flag struct {
@@ -16,6 +18,14 @@ func (flag Flag) String() string {
 		if value {
 			return fmt.Sprintf("--%s", flag.Key)
 		}
+	case []uint16:
+		ports := make([]string, len(value))
+
+		for i, port := range value {
+			ports[i] = strconv.Itoa(int(port))
+		}
+
+		return fmt.Sprintf("--%s=%s", flag.Key, strings.Join(ports, ","))
 	default:
 		return fmt.Sprintf("--%s=%v", flag.Key, value)
 	}
@@ -38,3 +48,20 @@ func Data(directory string) Flag {
 func Size(width int, height int) Flag {
 	return Flag{"window-size", fmt.Sprintf("%v,%v", width, height)}
 }
+
+// Headless controls whether Chromium runs without a visible UI. Omitting
+// this flag is equivalent to Headless(true), matching Start's historical
+// default.
+func Headless(enabled bool) Flag {
+	return Flag{"headless", enabled}
+}
+
+// ExplicitlyAllowedPorts adds to the set of ports Chromium is allowed to use
+// despite being on its built-in list of restricted ports (see
+// https://source.chromium.org/chromium/chromium/src/+/main:net/base/port_util.cc).
+// Start adds the chosen remote debugging port here automatically when it
+// falls in that list, so callers only need this for ports used elsewhere,
+// e.g. by the page under test.
+func ExplicitlyAllowedPorts(ports ...uint16) Flag {
+	return Flag{"explicitly-allowed-ports", ports}
+}
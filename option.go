@@ -0,0 +1,71 @@
+package chromium
+
+import "time"
+
+// Pseudo flag keys used to thread Go-level process management options
+// through the same Flag mechanism used for genuine Chromium command-line
+// flags. Flags using these keys are never forwarded to the chromium binary;
+// Start and Stop consume them instead.
+const (
+	optionGracePeriod = "$grace-period"
+	optionSandbox     = "$sandbox"
+	optionLogHandler  = "$log-handler"
+)
+
+// defaultGracePeriod is how long Stop waits for the process to exit on its
+// own, after asking it to close, before killing it with Process.Kill.
+const defaultGracePeriod = 5 * time.Second
+
+// GracePeriod sets how long StopContext waits for the process to exit
+// cleanly, after asking it to close via CDP Browser.close (or SIGTERM),
+// before killing it forcibly. The default grace period is 5 seconds.
+//
+// GracePeriod is a pseudo Flag: like Sandbox or WithLogHandler, it configures
+// this package's process management rather than being forwarded to the
+// chromium binary.
+func GracePeriod(d time.Duration) Flag {
+	return Flag{optionGracePeriod, d}
+}
+
+// Sandbox controls whether Start lets Chromium run with its own sandbox.
+// Without this option, Start only disables the sandbox (--no-sandbox) when
+// it detects it would otherwise fail to start: running as root on Linux,
+// where the sandbox refuses to initialize. Sandbox(false) always disables
+// it; Sandbox(true) always leaves it enabled, even as root.
+//
+// Sandbox is a pseudo Flag: it configures this package's launch policy
+// rather than being forwarded to the chromium binary.
+func Sandbox(enabled bool) Flag {
+	return Flag{optionSandbox, enabled}
+}
+
+// WithLogHandler registers handle to be called with every Entry parsed from
+// the process's stderr, regardless of Level. By default, Scan only forwards
+// Error and Fatal entries to Errors; WithLogHandler is for callers that also
+// want to observe INFO/WARNING/VERBOSE output or raw, unparseable lines.
+//
+// WithLogHandler is a pseudo Flag: it configures this package's logging
+// rather than being forwarded to the chromium binary.
+func WithLogHandler(handle func(Entry)) Flag {
+	return Flag{optionLogHandler, handle}
+}
+
+// isOption reports whether flag configures this package directly, as
+// opposed to being a genuine command-line flag to forward to chromium.
+func isOption(flag Flag) bool {
+	return len(flag.Key) > 0 && flag.Key[0] == '$'
+}
+
+// resolveOptions reads the pseudo Flag options out of chromium.flags into
+// their dedicated fields. It's called by StartContext for a launched
+// process and by Connect for a remote one, since the latter never goes
+// through Start.
+func (chromium *chromium) resolveOptions() {
+	if d, has := chromium.Flag(optionGracePeriod); has {
+		chromium.grace = d.(time.Duration)
+	}
+
+	if h, has := chromium.Flag(optionLogHandler); has {
+		chromium.logHandler = h.(func(Entry))
+	}
+}
@@ -0,0 +1,37 @@
+package chromium
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+// TestStopContextKillsAfterGracePeriod exercises the path that used to panic
+// with a nil pointer dereference: a process that ignores SIGTERM and never
+// exits on its own, forcing StopContext to fall back to Process.Kill once
+// the grace period elapses while its background goroutine is still running.
+func TestStopContextKillsAfterGracePeriod(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "trap '' TERM; sleep 5")
+
+	if err := cmd.Start(); err != nil {
+		t.Skipf("sh not available: %v", err)
+	}
+
+	chromium := &chromium{cmd: cmd, grace: 50 * time.Millisecond, errs: make(chan error, 1)}
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- chromium.StopContext(context.Background())
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("StopContext: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("StopContext did not return once the grace period elapsed")
+	}
+}
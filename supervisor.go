@@ -0,0 +1,259 @@
+package chromium
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// State is a Supervisor's lifecycle state, reported on the channel returned
+// by States.
+type State int
+
+const (
+	StateStopped State = iota
+	StateStarting
+	StateRunning
+	StateBackoff
+	StateFatal
+)
+
+func (state State) String() string {
+	switch state {
+	case StateStarting:
+		return "starting"
+	case StateRunning:
+		return "running"
+	case StateBackoff:
+		return "backoff"
+	case StateFatal:
+		return "fatal"
+	default:
+		return "stopped"
+	}
+}
+
+// AutoRestart controls when a Supervisor restarts a Chromium process after
+// it exits, mirroring supervisord's autorestart setting.
+type AutoRestart int
+
+const (
+	// OnFailure restarts the process only when it exits with an error,
+	// whether on startup or later. This is the default.
+	OnFailure AutoRestart = iota
+
+	// Always restarts the process regardless of how it exited.
+	Always
+
+	// Never leaves the process stopped once it exits, however it exited.
+	Never
+)
+
+// Supervisor watches a Chromium process and restarts it on unexpected exit,
+// using exponential backoff between attempts. This is useful for
+// long-running crawlers, where a crashed renderer would otherwise just hang
+// a caller's Wait forever.
+type Supervisor struct {
+	// Chromium is the process being supervised.
+	Chromium Chromium
+
+	// MaxRetries caps the number of consecutive restart attempts before the
+	// Supervisor gives up and transitions to Fatal. Zero, the default, means
+	// unlimited retries.
+	MaxRetries int
+
+	// StartSecs is how long the process must stay up to be considered
+	// Running rather than still Starting: exiting before then counts
+	// against MaxRetries and doubles the backoff delay, same as an outright
+	// failure to start. Defaults to 1 second.
+	StartSecs time.Duration
+
+	// AutoRestart controls whether and when the process is restarted after
+	// exiting. Defaults to OnFailure.
+	AutoRestart AutoRestart
+
+	states chan State
+
+	mutex sync.Mutex
+	fatal []func()
+}
+
+// NewSupervisor returns a Supervisor managing chromium, with its backoff and
+// restart policy left at their defaults. Set the exported fields before
+// calling Run to customize them.
+func NewSupervisor(chromium Chromium) *Supervisor {
+	return &Supervisor{
+		Chromium:  chromium,
+		StartSecs: time.Second,
+		states:    make(chan State, 1),
+	}
+}
+
+// States returns a channel of state transitions: Starting, Running,
+// Backoff, Fatal, and Stopped. The channel is buffered by one and only ever
+// holds the most recent transition; slow readers miss intermediate states
+// rather than blocking the Supervisor.
+func (supervisor *Supervisor) States() <-chan State {
+	return supervisor.states
+}
+
+// OnFatal registers hook to be called when the Supervisor transitions to
+// Fatal, e.g. to page someone when a crawler's renderer has given up
+// restarting.
+func (supervisor *Supervisor) OnFatal(hook func()) {
+	supervisor.mutex.Lock()
+	supervisor.fatal = append(supervisor.fatal, hook)
+	supervisor.mutex.Unlock()
+}
+
+// Run starts and supervises the process until it reaches a Fatal or
+// Stopped state. Run is equivalent to RunContext with context.Background.
+func (supervisor *Supervisor) Run() error {
+	return supervisor.RunContext(context.Background())
+}
+
+// RunContext is like Run but also stops supervising, tearing the process
+// down, once ctx is done.
+func (supervisor *Supervisor) RunContext(ctx context.Context) error {
+	retries := 0
+	backoff := time.Second
+
+	for {
+		supervisor.transition(StateStarting)
+
+		started := time.Now()
+		_, err := supervisor.Chromium.StartContext(ctx)
+
+		if err != nil {
+			if ctx.Err() != nil {
+				supervisor.stop()
+				supervisor.transition(StateStopped)
+				return ctx.Err()
+			}
+
+			if !supervisor.shouldRestart(true) {
+				supervisor.transition(StateStopped)
+				return err
+			}
+
+			retries++
+
+			if !supervisor.withinRetries(retries) {
+				supervisor.transition(StateFatal)
+				return err
+			}
+
+			supervisor.transition(StateBackoff)
+
+			if !supervisor.sleep(ctx, backoff) {
+				supervisor.transition(StateStopped)
+				return ctx.Err()
+			}
+
+			backoff *= 2
+			continue
+		}
+
+		supervisor.transition(StateRunning)
+
+		waitErr := supervisor.Chromium.WaitContext(ctx)
+
+		if ctx.Err() != nil {
+			supervisor.stop()
+			supervisor.transition(StateStopped)
+			return ctx.Err()
+		}
+
+		if !supervisor.shouldRestart(waitErr != nil) {
+			supervisor.transition(StateStopped)
+			return waitErr
+		}
+
+		if time.Since(started) < supervisor.StartSecs {
+			retries++
+		} else {
+			retries = 0
+			backoff = time.Second
+		}
+
+		if !supervisor.withinRetries(retries) {
+			supervisor.transition(StateFatal)
+			return waitErr
+		}
+
+		supervisor.transition(StateBackoff)
+
+		if !supervisor.sleep(ctx, backoff) {
+			supervisor.transition(StateStopped)
+			return ctx.Err()
+		}
+
+		backoff *= 2
+	}
+}
+
+// stop tears the supervised process down once RunContext's ctx is done,
+// making good on its doc comment's promise. It uses its own short-lived
+// timeout rather than the already-done ctx, since StopContext would
+// otherwise see a cancelled context and give up before the process even
+// gets a chance to exit cleanly.
+func (supervisor *Supervisor) stop() {
+	timeout, cancel := context.WithTimeout(context.Background(), defaultGracePeriod)
+	defer cancel()
+
+	supervisor.Chromium.StopContext(timeout)
+}
+
+func (supervisor *Supervisor) shouldRestart(failed bool) bool {
+	switch supervisor.AutoRestart {
+	case Never:
+		return false
+	case Always:
+		return true
+	default:
+		return failed
+	}
+}
+
+func (supervisor *Supervisor) withinRetries(retries int) bool {
+	return supervisor.MaxRetries == 0 || retries <= supervisor.MaxRetries
+}
+
+func (supervisor *Supervisor) sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (supervisor *Supervisor) transition(state State) {
+	// Drain a stale buffered transition before sending, so a slow reader
+	// always finds the most recent state waiting rather than whichever one
+	// happened to arrive first.
+	select {
+	case <-supervisor.states:
+	default:
+	}
+
+	select {
+	case supervisor.states <- state:
+	default:
+	}
+
+	if state != StateFatal {
+		return
+	}
+
+	supervisor.mutex.Lock()
+	hooks := append([]func(){}, supervisor.fatal...)
+	supervisor.mutex.Unlock()
+
+	for _, hook := range hooks {
+		hook()
+	}
+}
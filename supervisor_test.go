@@ -0,0 +1,85 @@
+package chromium_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	chromium "github.com/kasperisager/chromium"
+)
+
+// fakeChromium is a Chromium double that never exits on its own, so
+// RunContext's WaitContext call blocks until something tells it to stop.
+type fakeChromium struct {
+	stopped chan struct{}
+}
+
+func newFakeChromium() *fakeChromium {
+	return &fakeChromium{stopped: make(chan struct{})}
+}
+
+func (f *fakeChromium) Start() (uint16, error) { return f.StartContext(context.Background()) }
+func (f *fakeChromium) StartContext(ctx context.Context) (uint16, error) {
+	return 0, nil
+}
+
+func (f *fakeChromium) Stop() error { return f.StopContext(context.Background()) }
+func (f *fakeChromium) StopContext(ctx context.Context) error {
+	select {
+	case <-f.stopped:
+	default:
+		close(f.stopped)
+	}
+
+	return nil
+}
+
+func (f *fakeChromium) Wait() error { return f.WaitContext(context.Background()) }
+func (f *fakeChromium) WaitContext(ctx context.Context) error {
+	select {
+	case <-f.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (f *fakeChromium) Errors() <-chan error              { return nil }
+func (f *fakeChromium) Client() (*chromium.Client, error) { return nil, chromium.ErrNoPortAssigned }
+func (f *fakeChromium) WebSocketDebuggerURL() string      { return "" }
+
+// TestRunContextStopsOnCancel makes good on RunContext's doc comment: once
+// ctx is done, it must tear the supervised process down rather than
+// leaving it running in the background.
+func TestRunContextStopsOnCancel(t *testing.T) {
+	fake := newFakeChromium()
+	supervisor := chromium.NewSupervisor(fake)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var runErr atomic.Value
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		runErr.Store(supervisor.RunContext(ctx))
+	}()
+
+	// Give RunContext a moment to reach StateRunning before cancelling.
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("RunContext did not return after ctx was cancelled")
+	}
+
+	select {
+	case <-fake.stopped:
+	default:
+		t.Fatal("RunContext returned without stopping the supervised process")
+	}
+}